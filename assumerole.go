@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+const (
+	maxRoleChainDepth      = 5
+	defaultRoleSessionName = "mfaprof"
+)
+
+var (
+	errRoleChainTooDeep   = errors.New("role_arn/source_profile chain is too deep, possible cycle")
+	errNoSourceProfile    = errors.New("profile has role_arn but no source_profile")
+	errSourceProfileCreds = errors.New("cannot find static credentials for source_profile")
+)
+
+// roleConfig is the subset of a [profile] section in the shared config file
+// relevant to sts:AssumeRole.
+type roleConfig struct {
+	roleArn         string
+	sourceProfile   string
+	externalID      string
+	mfaSerial       string
+	roleSessionName string
+	durationSeconds int32
+}
+
+type stsAssumeRoleAPI interface {
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+var configSectionHeaderPattern = regexp.MustCompile(`^\[(?:profile\s+)?(.+?)\]\s*$`)
+
+// loadConfigSection returns the key/value pairs defined under the
+// [profile name] (or [default]) section of the given shared config file.
+func loadConfigSection(path, profile string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	inSection := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := configSectionHeaderPattern.FindStringSubmatch(trimmed); m != nil {
+			inSection = m[1] == profile
+			continue
+		}
+		if !inSection || trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		key, value, found := strings.Cut(trimmed, "=")
+		if !found {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// parseRoleConfig returns nil when the section has no role_arn, i.e. it's a
+// plain static-credentials profile rather than an assume-role one.
+func parseRoleConfig(values map[string]string) *roleConfig {
+	roleArn, ok := values["role_arn"]
+	if !ok || roleArn == "" {
+		return nil
+	}
+	role := &roleConfig{
+		roleArn:         roleArn,
+		sourceProfile:   values["source_profile"],
+		externalID:      values["external_id"],
+		mfaSerial:       values["mfa_serial"],
+		roleSessionName: values["role_session_name"],
+	}
+	if d, err := strconv.Atoi(values["duration_seconds"]); err == nil {
+		role.durationSeconds = int32(d)
+	}
+	return role
+}
+
+// stsClientWithCredentials builds an STS client that signs requests with a
+// fixed set of credentials instead of going through the default provider
+// chain, for use with creds obtained from a previous AssumeRole hop or read
+// directly from the shared credentials file.
+func stsClientWithCredentials(region string, creds types.Credentials) *sts.Client {
+	cfg := aws.Config{
+		Region: region,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				AccessKeyID:     *creds.AccessKeyId,
+				SecretAccessKey: *creds.SecretAccessKey,
+				SessionToken:    aws.ToString(creds.SessionToken),
+			}, nil
+		}),
+	}
+	return sts.NewFromConfig(cfg)
+}
+
+// newStaticSTSClientFactory builds the stsAssumeRoleAPI used to call
+// sts:AssumeRole, signing requests with whatever static credentials
+// (long-term or a previous hop's assumed-role creds) resolveSourceCredentials
+// hands it.
+func newStaticSTSClientFactory(region string) func(types.Credentials) stsAssumeRoleAPI {
+	return func(creds types.Credentials) stsAssumeRoleAPI {
+		return stsClientWithCredentials(region, creds)
+	}
+}
+
+// resolveSourceCredentials returns the access key/secret/session-token triple
+// to sign the AssumeRole call for profile. If profile is itself an
+// assume-role profile it is resolved recursively to support role chaining;
+// otherwise its static keys are read from the shared credentials file.
+func resolveSourceCredentials(ctx context.Context, newSTSClient func(types.Credentials) stsAssumeRoleAPI, configFile, credsFile, profile string, depth int) (*types.Credentials, error) {
+	configValues, err := loadConfigSection(configFile, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if role := parseRoleConfig(configValues); role != nil {
+		return assumeRole(ctx, newSTSClient, configFile, credsFile, profile, role, "", depth)
+	}
+
+	cf, err := loadCredentialsFile(credsFile)
+	if err != nil {
+		return nil, err
+	}
+	section := cf.section(profile)
+	accessKeyID, ok1 := section.get("aws_access_key_id")
+	secretAccessKey, ok2 := section.get("aws_secret_access_key")
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("%w: profile %q", errSourceProfileCreds, profile)
+	}
+	sessionToken, _ := section.get("aws_session_token")
+	return &types.Credentials{
+		AccessKeyId:     aws.String(accessKeyID),
+		SecretAccessKey: aws.String(secretAccessKey),
+		SessionToken:    aws.String(sessionToken),
+	}, nil
+}
+
+// assumeRole resolves role.sourceProfile's credentials (recursively, to
+// support role chaining) and calls sts:AssumeRole for profile's role_arn.
+// code is only sent as the MFA TokenCode when role.mfaSerial is set; it is
+// empty for the intermediate hops of a role chain.
+func assumeRole(ctx context.Context, newSTSClient func(types.Credentials) stsAssumeRoleAPI, configFile, credsFile, profile string, role *roleConfig, code string, depth int) (*types.Credentials, error) {
+	if depth > maxRoleChainDepth {
+		return nil, fmt.Errorf("%w: profile %q", errRoleChainTooDeep, profile)
+	}
+	if role.sourceProfile == "" {
+		return nil, fmt.Errorf("%w: profile %q", errNoSourceProfile, profile)
+	}
+
+	sourceCreds, err := resolveSourceCredentials(ctx, newSTSClient, configFile, credsFile, role.sourceProfile, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName := role.roleSessionName
+	if sessionName == "" {
+		sessionName = defaultRoleSessionName
+	}
+	input := &sts.AssumeRoleInput{
+		RoleArn:         &role.roleArn,
+		RoleSessionName: &sessionName,
+	}
+	if role.externalID != "" {
+		input.ExternalId = &role.externalID
+	}
+	if role.durationSeconds != 0 {
+		input.DurationSeconds = &role.durationSeconds
+	}
+	if role.mfaSerial != "" && code != "" {
+		input.SerialNumber = &role.mfaSerial
+		input.TokenCode = &code
+	}
+
+	out, err := newSTSClient(*sourceCreds).AssumeRole(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return out.Credentials, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	assumeRoleConfigFixture      = "./test/config-assume-role"
+	assumeRoleCredentialsFixture = "./test/credentials-assume-role"
+)
+
+// mockAssumeRoleAPI records every AssumeRole call it receives and returns
+// credentials derived from the requested role ARN, so tests can tell hops
+// apart without a real STS backend.
+type mockAssumeRoleAPI struct {
+	calls []*sts.AssumeRoleInput
+}
+
+func (m *mockAssumeRoleAPI) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.calls = append(m.calls, params)
+	return &sts.AssumeRoleOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("ASSUMED-" + *params.RoleArn),
+			SecretAccessKey: aws.String("assumed-secret"),
+			SessionToken:    aws.String("assumed-token"),
+		},
+	}, nil
+}
+
+func TestAssumeRoleSingleHop(t *testing.T) {
+	mock := &mockAssumeRoleAPI{}
+	factory := func(types.Credentials) stsAssumeRoleAPI { return mock }
+
+	values, err := loadConfigSection(assumeRoleConfigFixture, "single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	role := parseRoleConfig(values)
+	if role == nil {
+		t.Fatal("expected role config, got nil")
+	}
+
+	creds, err := assumeRole(context.Background(), factory, assumeRoleConfigFixture, assumeRoleCredentialsFixture, "single", role, "123456", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mock.calls) != 1 {
+		t.Fatalf("got %d AssumeRole calls, want 1", len(mock.calls))
+	}
+	call := mock.calls[0]
+	if *call.RoleArn != "arn:aws:iam::123456789012:role/single-role" {
+		t.Errorf("got role arn %q", *call.RoleArn)
+	}
+	if call.SerialNumber == nil || *call.SerialNumber != "arn:aws:iam::123456789123:mfa/user" {
+		t.Errorf("got serial number %v, want mfa serial", call.SerialNumber)
+	}
+	if call.TokenCode == nil || *call.TokenCode != "123456" {
+		t.Errorf("got token code %v, want 123456", call.TokenCode)
+	}
+	if got := *creds.AccessKeyId; got != "ASSUMED-arn:aws:iam::123456789012:role/single-role" {
+		t.Errorf("got access key %q", got)
+	}
+}
+
+func TestAssumeRoleChained(t *testing.T) {
+	mock := &mockAssumeRoleAPI{}
+	factory := func(types.Credentials) stsAssumeRoleAPI { return mock }
+
+	values, err := loadConfigSection(assumeRoleConfigFixture, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	role := parseRoleConfig(values)
+	if role == nil {
+		t.Fatal("expected role config, got nil")
+	}
+
+	creds, err := assumeRole(context.Background(), factory, assumeRoleConfigFixture, assumeRoleCredentialsFixture, "main", role, "654321", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mock.calls) != 2 {
+		t.Fatalf("got %d AssumeRole calls, want 2 (middle hop, then main)", len(mock.calls))
+	}
+
+	middleCall := mock.calls[0]
+	if *middleCall.RoleArn != "arn:aws:iam::123456789012:role/middle-role" {
+		t.Errorf("first call got role arn %q, want middle-role", *middleCall.RoleArn)
+	}
+	if middleCall.SerialNumber != nil {
+		t.Errorf("middle hop should not carry MFA, got serial %q", *middleCall.SerialNumber)
+	}
+	if *middleCall.RoleSessionName != "middle-session" {
+		t.Errorf("got role session name %q", *middleCall.RoleSessionName)
+	}
+
+	mainCall := mock.calls[1]
+	if *mainCall.RoleArn != "arn:aws:iam::123456789012:role/main-role" {
+		t.Errorf("second call got role arn %q, want main-role", *mainCall.RoleArn)
+	}
+	if mainCall.SerialNumber == nil || *mainCall.SerialNumber != "arn:aws:iam::123456789123:mfa/user" {
+		t.Errorf("got serial number %v, want mfa serial", mainCall.SerialNumber)
+	}
+	if mainCall.TokenCode == nil || *mainCall.TokenCode != "654321" {
+		t.Errorf("got token code %v, want 654321", mainCall.TokenCode)
+	}
+	if mainCall.ExternalId == nil || *mainCall.ExternalId != "externalid123" {
+		t.Errorf("got external id %v, want externalid123", mainCall.ExternalId)
+	}
+	if mainCall.DurationSeconds == nil || *mainCall.DurationSeconds != 1800 {
+		t.Errorf("got duration %v, want 1800", mainCall.DurationSeconds)
+	}
+
+	if got := *creds.AccessKeyId; got != "ASSUMED-arn:aws:iam::123456789012:role/main-role" {
+		t.Errorf("got access key %q", got)
+	}
+}
+
+func TestAssumeRoleMissingSourceProfile(t *testing.T) {
+	mock := &mockAssumeRoleAPI{}
+	factory := func(types.Credentials) stsAssumeRoleAPI { return mock }
+	role := &roleConfig{roleArn: "arn:aws:iam::123456789012:role/no-source"}
+
+	_, err := assumeRole(context.Background(), factory, assumeRoleConfigFixture, assumeRoleCredentialsFixture, "orphan", role, "123456", 0)
+	if err == nil {
+		t.Fatal("expected an error for a role_arn profile without source_profile")
+	}
+}
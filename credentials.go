@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+const credentialsFilePerm = 0o600
+
+var credentialsSectionPattern = regexp.MustCompile(`^\[([^\]]+)\]\s*$`)
+
+// credentialsSection holds the raw lines (key = value pairs, comments and
+// blank lines) belonging to one [profile] section, in their original order.
+type credentialsSection struct {
+	name  string
+	lines []string
+}
+
+// get returns the value of an existing "key = value" line, if any.
+func (s *credentialsSection) get(key string) (string, bool) {
+	pattern := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=\s*(.*)$`)
+	for _, l := range s.lines {
+		if m := pattern.FindStringSubmatch(l); m != nil {
+			return strings.TrimSpace(m[1]), true
+		}
+	}
+	return "", false
+}
+
+// set replaces the value of an existing "key = value" line or appends a new
+// one, preserving every other line (including comments) untouched.
+func (s *credentialsSection) set(key, value string) {
+	pattern := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=`)
+	line := fmt.Sprintf("%s = %s", key, value)
+	for i, l := range s.lines {
+		if pattern.MatchString(l) {
+			s.lines[i] = line
+			return
+		}
+	}
+	s.lines = append(s.lines, line)
+}
+
+// credentialsFile is a parsed AWS shared credentials file. Comments, blank
+// lines and section ordering are preserved so that updating one profile
+// doesn't disturb the rest of the file.
+type credentialsFile struct {
+	preamble []string
+	sections []*credentialsSection
+}
+
+// section returns the named section, creating and appending an empty one if
+// it doesn't already exist.
+func (cf *credentialsFile) section(name string) *credentialsSection {
+	for _, s := range cf.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &credentialsSection{name: name}
+	cf.sections = append(cf.sections, s)
+	return s
+}
+
+func (cf *credentialsFile) bytes() []byte {
+	var b strings.Builder
+	for _, l := range cf.preamble {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	for _, s := range cf.sections {
+		fmt.Fprintf(&b, "[%s]\n", s.name)
+		for _, l := range s.lines {
+			b.WriteString(l)
+			b.WriteString("\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+func parseCredentialsFile(r io.Reader) (*credentialsFile, error) {
+	cf := &credentialsFile{}
+	var current *credentialsSection
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := credentialsSectionPattern.FindStringSubmatch(line); m != nil {
+			current = cf.section(m[1])
+			continue
+		}
+		if current == nil {
+			cf.preamble = append(cf.preamble, line)
+			continue
+		}
+		current.lines = append(current.lines, line)
+	}
+	return cf, scanner.Err()
+}
+
+func loadCredentialsFile(path string) (*credentialsFile, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &credentialsFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCredentialsFile(f)
+}
+
+// writeCredentialsFileAtomic writes data to a temporary file in the same
+// directory as path, fsyncs it, then renames it into place so readers never
+// observe a partially written credentials file.
+func writeCredentialsFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, credentialsFilePerm)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, credentialsFilePerm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// saveCredentialsProfile writes the temporary session credentials into the
+// named profile of the shared credentials file at path directly, without
+// shelling out to `aws configure set`. Concurrent
+// mfaprof runs are serialized with a file lock so they can't interleave
+// writes and corrupt the file.
+func saveCredentialsProfile(path, name, region string, creds *types.Credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	lock, err := lockCredentialsFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	cf, err := loadCredentialsFile(path)
+	if err != nil {
+		return err
+	}
+
+	section := cf.section(name)
+	section.set("aws_access_key_id", *creds.AccessKeyId)
+	section.set("aws_secret_access_key", *creds.SecretAccessKey)
+	section.set("aws_session_token", *creds.SessionToken)
+	if region != "" {
+		section.set("region", region)
+	}
+	section.set("aws_session_expiration", creds.Expiration.UTC().Format(time.RFC3339))
+
+	return writeCredentialsFileAtomic(path, cf.bytes())
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// fileLock guards the shared credentials file against concurrent mfaprof
+// runs clobbering each other's writes.
+type fileLock struct {
+	f *os.File
+}
+
+// lockCredentialsFile has no flock equivalent on Windows wired up here; the
+// atomic rename in writeCredentialsFileAtomic still prevents readers from
+// observing a half-written file, it just doesn't serialize concurrent writers.
+func lockCredentialsFile(path string) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+func (l *fileLock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const credentialsFixture = "./test/credentials-with-comments"
+
+func TestParseCredentialsFile(t *testing.T) {
+	f, err := os.Open(credentialsFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cf, err := parseCredentialsFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cf.preamble) != 1 || cf.preamble[0] != "# managed by hand, please be careful" {
+		t.Errorf("unexpected preamble: %q", cf.preamble)
+	}
+	if len(cf.sections) != 2 {
+		t.Fatalf("got %d sections, want 2", len(cf.sections))
+	}
+	if cf.sections[0].name != "default" || cf.sections[1].name != "named" {
+		t.Errorf("unexpected section order: %q, %q", cf.sections[0].name, cf.sections[1].name)
+	}
+	named := cf.section("named")
+	if !strings.Contains(strings.Join(named.lines, "\n"), "region = eu-west-1") {
+		t.Errorf("named section missing region: %q", named.lines)
+	}
+}
+
+func TestCredentialsSectionSet(t *testing.T) {
+	s := &credentialsSection{name: "named", lines: []string{"aws_access_key_id = OLD"}}
+
+	s.set("aws_access_key_id", "NEW")
+	s.set("aws_secret_access_key", "SECRET")
+
+	if len(s.lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(s.lines), s.lines)
+	}
+	if s.lines[0] != "aws_access_key_id = NEW" {
+		t.Errorf("got %q, want updated in place", s.lines[0])
+	}
+	if s.lines[1] != "aws_secret_access_key = SECRET" {
+		t.Errorf("got %q, want appended", s.lines[1])
+	}
+}
+
+func TestSaveCredentialsProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials")
+	fixture, err := os.ReadFile(credentialsFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fixture, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Date(2026, 7, 25, 12, 0, 0, 0, time.UTC)
+	stsOutput := &sts.GetSessionTokenOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("AKIANEW"),
+			SecretAccessKey: aws.String("newsecret"),
+			SessionToken:    aws.String("sessiontoken"),
+			Expiration:      &expires,
+		},
+	}
+
+	if err := saveCredentialsProfile(path, "named_mfa", "us-east-1", stsOutput.Credentials); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotStr := string(got)
+
+	for _, want := range []string{
+		"# managed by hand, please be careful",
+		"[default]",
+		"aws_access_key_id = AKIADEFAULT",
+		"[named]",
+		"region = eu-west-1",
+		"[named_mfa]",
+		"aws_access_key_id = AKIANEW",
+		"aws_secret_access_key = newsecret",
+		"aws_session_token = sessiontoken",
+		"region = us-east-1",
+		"aws_session_expiration = 2026-07-25T12:00:00Z",
+	} {
+		if !strings.Contains(gotStr, want) {
+			t.Errorf("output missing %q, got:\n%s", want, gotStr)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != credentialsFilePerm {
+		t.Errorf("got perm %o, want %o", perm, credentialsFilePerm)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("leftover tmp file: %v", err)
+	}
+}
+
+func TestSaveCredentialsProfileCreatesNewFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "credentials")
+
+	expires := time.Date(2026, 7, 25, 12, 0, 0, 0, time.UTC)
+	stsOutput := &sts.GetSessionTokenOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("AKIANEW"),
+			SecretAccessKey: aws.String("newsecret"),
+			SessionToken:    aws.String("sessiontoken"),
+			Expiration:      &expires,
+		},
+	}
+
+	if err := saveCredentialsProfile(path, "named_mfa", "", stsOutput.Credentials); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "[named_mfa]") {
+		t.Errorf("output missing new section, got:\n%s", string(got))
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const importSeedCommand = "import-seed"
+
+var errMissingImportSeedArg = errors.New("-profile and -device are required")
+
+type importSeedOptions struct {
+	profile, device, seedFile string
+	debug, quiet              bool
+}
+
+func parseImportSeedFlags(args []string) *importSeedOptions {
+	fs := flag.NewFlagSet(importSeedCommand, flag.ExitOnError)
+	opt := importSeedOptions{}
+	fs.StringVar(&opt.profile, "profile", "", "(Required) Name of the AWS CLI profile this TOTP secret will be used with.")
+	fs.StringVar(&opt.device, "device", "", "(Required) Serial number/ARN of the MFA device the secret belongs to.")
+	fs.StringVar(&opt.seedFile, "seed-file", "",
+		"(Optional) Path to a base32 seed file, e.g. the one written by `mfaprof provision`. "+
+			"When omitted, the seed is read from an interactive prompt.")
+	fs.BoolVar(&opt.debug, "debug", false, "(Optional) Enables debug messages, ignores quiet flag.")
+	fs.BoolVar(&opt.quiet, "quiet", false, "(Optional) Suppress non-debug messages.")
+	fs.Parse(args)
+	return &opt
+}
+
+// readSeed returns the base32 TOTP seed, trimmed of surrounding whitespace,
+// as a slice sharing the backing array it was read into, so the caller can
+// zero that array once the seed has been stored in the keyring. Converting
+// the seed to a string here would copy it into an immutable allocation that
+// zeroBytes can never reach.
+func readSeed(path string) ([]byte, error) {
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimSpace(raw), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Enter the base32 TOTP seed: ")
+	raw, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(raw), nil
+}
+
+// runImportSeedCommand is the entry point for `mfaprof import-seed ...`. It
+// stores a base32 TOTP secret in the OS keyring, keyed by {profile, device},
+// so that `-totp-from-keyring` can later generate codes without an
+// interactive prompt.
+func runImportSeedCommand(args []string) {
+	opt := parseImportSeedFlags(args)
+	debug = opt.debug
+	if !debug {
+		quiet = opt.quiet
+	}
+
+	if opt.profile == "" || opt.device == "" {
+		log.Fatal(errMissingImportSeedArg)
+	}
+
+	seed, err := readSeed(opt.seedFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer zeroBytes(seed)
+
+	if err := keyringSet(opt.profile, opt.device, seed); err != nil {
+		log.Fatal(err)
+	}
+	logMsg("Stored TOTP seed in the OS keyring for profile %q, device %q", opt.profile, opt.device)
+}
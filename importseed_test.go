@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSeedFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "seed.txt")
+	if err := os.WriteFile(path, []byte("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	seed, err := readSeed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(seed) != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Errorf("got %q, want trimmed seed", seed)
+	}
+}
+
+func TestReadSeedFromFileNotFound(t *testing.T) {
+	if _, err := readSeed(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing seed file")
+	}
+}
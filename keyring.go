@@ -0,0 +1,30 @@
+package main
+
+import "errors"
+
+const keyringService = "mfaprof"
+
+var (
+	errKeyringUnsupportedOS  = errors.New("keyring storage is not supported on this OS")
+	errKeyringSecretNotFound = errors.New("no TOTP secret found in the keyring for this profile/device")
+)
+
+// keyringAccount is the lookup key for a profile's TOTP secret: the shared
+// credentials profile name and the MFA device serial together, so the same
+// device can be imported under more than one profile without clashing.
+func keyringAccount(profile, deviceSerial string) string {
+	return profile + "|" + deviceSerial
+}
+
+// keyringSet stores secret in the OS-native credential store, following the
+// same per-OS backend choice as aws-vault: Keychain on macOS, libsecret on
+// Linux, Credential Manager on Windows. Any other OS returns
+// errKeyringUnsupportedOS.
+func keyringSet(profile, deviceSerial string, secret []byte) error {
+	return keyringBackendSet(keyringAccount(profile, deviceSerial), secret)
+}
+
+// keyringGet retrieves a secret previously stored with keyringSet.
+func keyringGet(profile, deviceSerial string) (string, error) {
+	return keyringBackendGet(keyringAccount(profile, deviceSerial))
+}
@@ -0,0 +1,38 @@
+//go:build darwin
+
+package main
+
+import "github.com/keybase/go-keychain"
+
+// keyringBackendSet talks to Keychain Services directly via the Security
+// framework (cgo), the same approach aws-vault uses, instead of shelling out
+// to the `security` CLI: a `security add-generic-password -w secret` argument
+// would sit in this process's argv for the life of the call, readable by any
+// local user via ps(1) or /proc/<pid>/cmdline.
+func keyringBackendSet(account string, secret []byte) error {
+	item := keychain.NewGenericPassword(keyringService, account, "", secret, "")
+	item.SetAccessible(keychain.AccessibleWhenUnlocked)
+	item.SetSynchronizable(keychain.SynchronizableNo)
+	err := keychain.AddItem(item)
+	if err == keychain.ErrorDuplicateItem {
+		return keychain.UpdateItem(
+			keychain.NewGenericPassword(keyringService, account, "", nil, ""),
+			item,
+		)
+	}
+	return err
+}
+
+func keyringBackendGet(account string) (string, error) {
+	query := keychain.NewGenericPassword(keyringService, account, "", nil, "")
+	query.SetMatchLimit(keychain.MatchLimitOne)
+	query.SetReturnData(true)
+	results, err := keychain.QueryItem(query)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", errKeyringSecretNotFound
+	}
+	return string(results[0].Data), nil
+}
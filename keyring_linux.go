@@ -0,0 +1,30 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keyringBackendSet shells out to secret-tool (libsecret), the same backend
+// aws-vault uses on Linux. The secret is piped over stdin rather than passed
+// as a CLI argument so it never appears in this process's argv.
+func keyringBackendSet(account string, secret []byte) error {
+	cmd := exec.Command("secret-tool", "store",
+		"--label", fmt.Sprintf("mfaprof TOTP secret (%s)", account),
+		"service", keyringService, "account", account)
+	cmd.Stdin = bytes.NewReader(secret)
+	return cmd.Run()
+}
+
+func keyringBackendGet(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup",
+		"service", keyringService, "account", account).Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errKeyringSecretNotFound, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
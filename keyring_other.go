@@ -0,0 +1,11 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+func keyringBackendSet(account string, secret []byte) error {
+	return errKeyringUnsupportedOS
+}
+
+func keyringBackendGet(account string) (string, error) {
+	return "", errKeyringUnsupportedOS
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package main
+
+import "github.com/danieljoos/wincred"
+
+// keyringBackendSet stores secret in Windows Credential Manager via the
+// native CredWriteW API (as wrapped by danieljoos/wincred), the same backend
+// aws-vault uses on Windows.
+func keyringBackendSet(account string, secret []byte) error {
+	cred := wincred.NewGenericCredential(keyringService + ":" + account)
+	cred.CredentialBlob = secret
+	return cred.Write()
+}
+
+// keyringBackendGet retrieves a secret previously stored with
+// keyringBackendSet via the native CredReadW API.
+func keyringBackendGet(account string) (string, error) {
+	cred, err := wincred.GetGenericCredential(keyringService + ":" + account)
+	if err != nil {
+		return "", errKeyringSecretNotFound
+	}
+	return string(cred.CredentialBlob), nil
+}
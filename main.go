@@ -7,12 +7,12 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"regexp"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 )
 
 const (
@@ -34,11 +34,17 @@ var (
 	errProfileDoesNotExist     = errors.New("profile does not exist")
 	errNoDevicesAssociated     = errors.New("there are no MFA devices associated with this user")
 	errCannotListDevices       = errors.New("cannot list MFA devices")
+	errInvalidShell            = errors.New("shell must be one of bash, zsh, fish, powershell")
+	errInvalidTOTPWindow       = errors.New("totp-window must not be negative")
 )
 
+var supportedShells = []string{"bash", "zsh", "fish", "powershell"}
+
 type options struct {
-	profile, name, code, device, region string
-	debug, quiet                        bool
+	profile, name, code, device, region, shell string
+	totpFromKeyring                            bool
+	totpWindow                                 int
+	debug, quiet                               bool
 }
 
 type iamListMFADevicesAPI interface {
@@ -70,15 +76,32 @@ func parseFlags() *options {
 			"Default: the first MFA device from ListMFADevices API call.")
 	flag.BoolVar(&opt.debug, "debug", false, "(Optional) Enables debug messages, ignores quiet flag.")
 	flag.BoolVar(&opt.quiet, "quiet", false, "(Optional) Suppress non-debug messages.")
+	flag.StringVar(&opt.shell, "shell", "",
+		"(Optional) Print eval-ready export statements for the given shell (bash, zsh, fish, powershell) to stdout "+
+			"instead of writing a profile, e.g. eval $(mfaprof -shell bash).")
+	flag.BoolVar(&opt.totpFromKeyring, "totp-from-keyring", false,
+		"(Optional) Generate the MFA code locally from a TOTP secret previously stored with `mfaprof import-seed`, "+
+			"instead of prompting for one. Falls back to the interactive prompt if no secret is found.")
+	flag.IntVar(&opt.totpWindow, "totp-window", 0,
+		"(Optional) With -totp-from-keyring, also try codes from this many 30-second steps before and after the current one, "+
+			"to tolerate local clock drift.")
 
 	flag.Parse()
 	return &opt
 }
 
 func validateFlags(opt *options, configFilename string) error {
-	codeInputValid, _ := regexp.MatchString(awsMfaCodePattern, opt.code)
-	if !codeInputValid {
-		return errInvalidCode
+	// With -totp-from-keyring and no explicit -code, the code is generated
+	// later once the MFA device is known, so an empty one is fine here.
+	if opt.code != "" || !opt.totpFromKeyring {
+		codeInputValid, _ := regexp.MatchString(awsMfaCodePattern, opt.code)
+		if !codeInputValid {
+			return errInvalidCode
+		}
+	}
+
+	if opt.totpWindow < 0 {
+		return errInvalidTOTPWindow
 	}
 
 	if opt.device != "" {
@@ -88,6 +111,19 @@ func validateFlags(opt *options, configFilename string) error {
 		}
 	}
 
+	if opt.shell != "" {
+		shellValid := false
+		for _, s := range supportedShells {
+			if opt.shell == s {
+				shellValid = true
+				break
+			}
+		}
+		if !shellValid {
+			return errInvalidShell
+		}
+	}
+
 	awsCliProfileNameRegexp := regexp.MustCompile(awsCliProfileNamePattern)
 	if nameInputValid := awsCliProfileNameRegexp.MatchString(opt.name); !nameInputValid {
 		return errInvalidProfileName
@@ -122,49 +158,39 @@ func getFirstDevice(api iamListMFADevicesAPI) (string, error) {
 	return *mfaDevices.MFADevices[0].SerialNumber, nil
 }
 
-func saveNewProfile(name string, region string, stsOutput *sts.GetSessionTokenOutput) error {
-	// cmd.Run() doesn't invoke shell and doesn't evaluate globs
-	logMsg("Running command 1 out of 4: aws configure set aws_access_key_id <VALUE> --profile %s", name)
-	err := exec.Command(
-		"aws", "configure", "set", "aws_access_key_id",
-		*stsOutput.Credentials.AccessKeyId, "--profile", name).Run()
-	if err != nil {
-		return err
-	}
-	logMsg("Running command 2 out of 4: aws configure set aws_secret_access_key <VALUE> --profile %s", name)
-	err = exec.Command(
-		"aws", "configure", "set", "aws_secret_access_key",
-		*stsOutput.Credentials.SecretAccessKey, "--profile", name).Run()
-	if err != nil {
-		return err
+// promptForCode asks for an MFA code on stdout, or stderr in -shell mode so
+// `eval $(mfaprof -shell bash ...)` only evaluates export statements.
+func promptForCode(shell string) string {
+	promptDest := os.Stdout
+	if shell != "" {
+		promptDest = os.Stderr
 	}
-	logMsg("Running command 3 out of 4: aws configure set aws_session_token <VALUE> --profile %s", name)
-	err = exec.Command(
-		"aws", "configure", "set", "aws_session_token",
-		*stsOutput.Credentials.SessionToken, "--profile", name).Run()
-	if err != nil {
-		return err
-	}
-	logMsg("Running command 4 out of 4: aws configure set region %s --profile %s", region, name)
-	err = exec.Command(
-		"aws", "configure", "set", "region",
-		region, "--profile", name).Run()
-	if err != nil {
-		return err
-	}
-	return nil
+	fmt.Fprint(promptDest, "Enter MFA code: ")
+	var code string
+	fmt.Scanln(&code)
+	return code
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case provisionCommand:
+			runProvisionCommand(os.Args[2:])
+			return
+		case importSeedCommand:
+			runImportSeedCommand(os.Args[2:])
+			return
+		}
+	}
+
 	opt := parseFlags()
 	debug = opt.debug
 	if !debug {
 		quiet = opt.quiet
 	}
 
-	if opt.code == "" {
-		fmt.Print("Enter MFA code: ")
-		fmt.Scanln(&opt.code)
+	if opt.code == "" && !opt.totpFromKeyring {
+		opt.code = promptForCode(opt.shell)
 	}
 
 	// We need to resolve shared config filename to validate that the provided profile exists
@@ -176,6 +202,11 @@ func main() {
 	if envConfig.SharedConfigFile != "" {
 		configFile = envConfig.SharedConfigFile
 	}
+	credentialsFile := config.DefaultSharedCredentialsFilename()
+	if envConfig.SharedCredentialsFile != "" {
+		credentialsFile = envConfig.SharedCredentialsFile
+	}
+	debugMsg("Using shared credentials file %q", credentialsFile)
 	debugMsg("Using shared config file %q", configFile)
 	if opt.profile == "" {
 		if envConfig.SharedConfigProfile != "" {
@@ -197,6 +228,12 @@ func main() {
 		log.Fatal(err)
 	}
 
+	configValues, err := loadConfigSection(configFile, opt.profile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	role := parseRoleConfig(configValues)
+
 	cfg, err := config.LoadDefaultConfig(context.TODO(),
 		config.WithSharedConfigProfile(opt.profile), // value is ignored when empty
 	)
@@ -207,7 +244,13 @@ func main() {
 	opt.region = cfg.Region
 	debugMsg("Detected region: %s", opt.region)
 
-	if opt.device == "" {
+	if role != nil {
+		if opt.device == "" {
+			opt.device = role.mfaSerial
+		} else {
+			role.mfaSerial = opt.device
+		}
+	} else if opt.device == "" {
 		logMsg("No MFA device serial number provided, getting one from ListMFADevices")
 		opt.device, err = getFirstDevice(iam.NewFromConfig(cfg))
 		if err != nil {
@@ -215,17 +258,64 @@ func main() {
 		}
 	}
 
+	var codeCandidates []string
+	if opt.code == "" && opt.totpFromKeyring {
+		if secret, kerr := keyringGet(opt.profile, opt.device); kerr == nil {
+			codeCandidates = totpCandidates(secret, opt.totpWindow)
+			debugMsg("Generated %d local TOTP candidate code(s) from the keyring secret", len(codeCandidates))
+		}
+		if len(codeCandidates) == 0 {
+			debugMsg("No usable TOTP keyring secret found, falling back to the interactive prompt")
+			opt.code = promptForCode(opt.shell)
+		}
+	}
+	if codeCandidates == nil {
+		codeCandidates = []string{opt.code}
+	}
+
 	logMsg("Getting temporary credentials")
-	stsOutput, err := sts.NewFromConfig(cfg).GetSessionToken(
-		context.TODO(),
-		&sts.GetSessionTokenInput{SerialNumber: &opt.device, TokenCode: &opt.code},
-	)
+	var creds *types.Credentials
+	stsClient := sts.NewFromConfig(cfg)
+	for _, code := range codeCandidates {
+		if role != nil {
+			debugMsg("Profile %q has a role_arn, assuming it via source_profile %q", opt.profile, role.sourceProfile)
+			creds, err = assumeRole(context.TODO(), newStaticSTSClientFactory(opt.region), configFile, credentialsFile, opt.profile, role, code, 0)
+		} else {
+			var stsOutput *sts.GetSessionTokenOutput
+			stsOutput, err = stsClient.GetSessionToken(
+				context.TODO(),
+				&sts.GetSessionTokenInput{SerialNumber: &opt.device, TokenCode: &code},
+			)
+			if err == nil {
+				creds = stsOutput.Credentials
+			}
+		}
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if opt.shell != "" {
+		logMsg("Getting caller identity")
+		stsClient := sts.NewFromConfig(cfg)
+		if role != nil {
+			stsClient = stsClientWithCredentials(opt.region, *creds)
+		}
+		identity, err := stsClient.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := printShellExports(os.Stdout, opt.shell, opt.region, identity, creds); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	logMsg("Saving new profile")
-	err = saveNewProfile(opt.name, opt.region, stsOutput)
+	err = saveCredentialsProfile(credentialsFile, opt.name, opt.region, creds)
 	if err != nil {
 		log.Fatal(err)
 	}
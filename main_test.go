@@ -44,6 +44,8 @@ func TestValidateFlags(t *testing.T) {
 		{"wrongDeviceSerial", options{profile: defaultProfile, code: "123456", name: namedProfile, device: badInput}, configFile, errInvalidDevice},
 		{"wrongResultingProfileName", options{profile: defaultProfile, code: "123456", name: badInput}, configFile, errInvalidProfileName},
 		{"wrongProfileName", options{profile: badInput, code: "123456", name: namedProfile}, configFile, errInvalidProfileName},
+		{"invalidShell", options{profile: defaultProfile, code: "123456", name: namedProfile, shell: "tcsh"}, configFile, errInvalidShell},
+		{"negativeTotpWindow", options{profile: defaultProfile, code: "123456", name: namedProfile, totpWindow: -1}, configFile, errInvalidTOTPWindow},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/mdp/qrterminal/v3"
+)
+
+const provisionCommand = "provision"
+
+var (
+	errSeedFileExists      = errors.New("seed file already exists, pass -force to overwrite")
+	errEnableMFAFailed     = errors.New("could not enable MFA device, check the two codes and try again")
+	errMissingProvisionArg = errors.New("-user and -name are required")
+)
+
+type provisionOptions struct {
+	user, name, outDir  string
+	force, debug, quiet bool
+	printQR             bool
+}
+
+// iamMFAAPI is the full MFA device lifecycle surface `mfaprof provision`
+// needs, extending iamListMFADevicesAPI with the calls to create and
+// activate a virtual device.
+type iamMFAAPI interface {
+	iamListMFADevicesAPI
+	CreateVirtualMFADevice(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error)
+	EnableMFADevice(ctx context.Context, params *iam.EnableMFADeviceInput, optFns ...func(*iam.Options)) (*iam.EnableMFADeviceOutput, error)
+}
+
+func parseProvisionFlags(args []string) *provisionOptions {
+	fs := flag.NewFlagSet(provisionCommand, flag.ExitOnError)
+	opt := provisionOptions{}
+	fs.StringVar(&opt.user, "user", "", "(Required) Name of the IAM user to provision a virtual MFA device for.")
+	fs.StringVar(&opt.name, "name", "", "(Required) Name of the new virtual MFA device.")
+	fs.StringVar(&opt.outDir, "out", ".", "(Optional) Directory to write the seed and QR code PNG files to.")
+	fs.BoolVar(&opt.force, "force", false, "(Optional) Overwrite an existing seed file for this device name.")
+	fs.BoolVar(&opt.printQR, "qr", false, "(Optional) Also render the provisioning QR code as ASCII art in the terminal, "+
+		"for authenticator apps that can scan a screen but not the PNG file.")
+	fs.BoolVar(&opt.debug, "debug", false, "(Optional) Enables debug messages, ignores quiet flag.")
+	fs.BoolVar(&opt.quiet, "quiet", false, "(Optional) Suppress non-debug messages.")
+	fs.Parse(args)
+	return &opt
+}
+
+// readCodeFromStdin prompts on stdout and reads a single line from r, trimmed
+// of surrounding whitespace. Callers must reuse the same *bufio.Reader across
+// both prompts: wrapping os.Stdin in a fresh bufio.Reader per call discards
+// whatever that reader already buffered from a piped/scripted stdin, losing
+// the second code.
+func readCodeFromStdin(r *bufio.Reader, prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// provisioningURI builds an otpauth:// URI for seed, labelled with the IAM
+// user name, so the same secret as in device.QRCodePNG can be rendered as an
+// ASCII QR code in the terminal instead of decoding the PNG.
+func provisioningURI(user string, seed []byte) string {
+	v := url.Values{}
+	v.Set("secret", string(seed))
+	v.Set("issuer", "AWS")
+	return fmt.Sprintf("otpauth://totp/AWS:%s?%s", url.PathEscape(user), v.Encode())
+}
+
+// printQRCode renders uri as an ASCII QR code on stdout, for authenticator
+// apps that can scan a terminal screen but not the PNG file written to disk.
+func printQRCode(uri string) {
+	qrterminal.GenerateHalfBlock(uri, qrterminal.L, os.Stdout)
+}
+
+// runProvision creates a virtual MFA device for opt.user, writes its seed
+// and QR code PNG to opt.outDir, then activates it once two consecutive
+// codes (read via readCode) are provided.
+func runProvision(api iamMFAAPI, opt *provisionOptions, readCode func(prompt string) (string, error)) error {
+	seedPath := filepath.Join(opt.outDir, opt.name+".seed")
+	pngPath := filepath.Join(opt.outDir, opt.name+".png")
+
+	if !opt.force {
+		if _, err := os.Stat(seedPath); err == nil {
+			return errSeedFileExists
+		}
+	}
+
+	logMsg("Creating virtual MFA device %q", opt.name)
+	out, err := api.CreateVirtualMFADevice(context.TODO(), &iam.CreateVirtualMFADeviceInput{
+		VirtualMFADeviceName: &opt.name,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create virtual MFA device: %w", err)
+	}
+	device := out.VirtualMFADevice
+
+	seed := device.Base32StringSeed
+	defer zeroBytes(seed)
+
+	if err := os.WriteFile(seedPath, seed, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pngPath, device.QRCodePNG, 0o600); err != nil {
+		return err
+	}
+	logMsg("Seed written to %s, QR code written to %s", seedPath, pngPath)
+	if opt.printQR {
+		printQRCode(provisioningURI(opt.user, seed))
+	}
+	logMsg("Scan the QR code (or enter the seed manually) in your authenticator app, then provide two consecutive codes")
+
+	code1, err := readCode("Enter the first MFA code: ")
+	if err != nil {
+		return err
+	}
+	code2, err := readCode("Enter the second MFA code: ")
+	if err != nil {
+		return err
+	}
+	codePattern := regexp.MustCompile(awsMfaCodePattern)
+	if !codePattern.MatchString(code1) || !codePattern.MatchString(code2) {
+		return errInvalidCode
+	}
+
+	logMsg("Enabling MFA device for user %q", opt.user)
+	_, err = api.EnableMFADevice(context.TODO(), &iam.EnableMFADeviceInput{
+		UserName:            &opt.user,
+		SerialNumber:        device.SerialNumber,
+		AuthenticationCode1: &code1,
+		AuthenticationCode2: &code2,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errEnableMFAFailed, err)
+	}
+
+	logMsg("MFA device %s enabled for user %s", *device.SerialNumber, opt.user)
+	return nil
+}
+
+// runProvisionCommand is the entry point for `mfaprof provision ...`.
+func runProvisionCommand(args []string) {
+	opt := parseProvisionFlags(args)
+	debug = opt.debug
+	if !debug {
+		quiet = opt.quiet
+	}
+
+	if opt.user == "" || opt.name == "" {
+		log.Fatal(errMissingProvisionArg)
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	readCode := func(prompt string) (string, error) {
+		return readCodeFromStdin(stdin, prompt)
+	}
+	if err := runProvision(iam.NewFromConfig(cfg), opt, readCode); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+type mockIAMMFAAPI struct {
+	createFn func(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error)
+	enableFn func(ctx context.Context, params *iam.EnableMFADeviceInput, optFns ...func(*iam.Options)) (*iam.EnableMFADeviceOutput, error)
+	listFn   func(ctx context.Context, params *iam.ListMFADevicesInput, optFns ...func(*iam.Options)) (*iam.ListMFADevicesOutput, error)
+}
+
+func (m mockIAMMFAAPI) CreateVirtualMFADevice(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error) {
+	return m.createFn(ctx, params, optFns...)
+}
+
+func (m mockIAMMFAAPI) EnableMFADevice(ctx context.Context, params *iam.EnableMFADeviceInput, optFns ...func(*iam.Options)) (*iam.EnableMFADeviceOutput, error) {
+	return m.enableFn(ctx, params, optFns...)
+}
+
+func (m mockIAMMFAAPI) ListMFADevices(ctx context.Context, params *iam.ListMFADevicesInput, optFns ...func(*iam.Options)) (*iam.ListMFADevicesOutput, error) {
+	return m.listFn(ctx, params, optFns...)
+}
+
+func codeReader(codes ...string) func(string) (string, error) {
+	i := 0
+	return func(string) (string, error) {
+		c := codes[i]
+		i++
+		return c, nil
+	}
+}
+
+func TestRunProvisionHappyPath(t *testing.T) {
+	dir := t.TempDir()
+	opt := &provisionOptions{user: "alice", name: "alice-device", outDir: dir}
+
+	var enabledSerial, enabledCode1, enabledCode2 string
+	api := mockIAMMFAAPI{
+		createFn: func(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error) {
+			return &iam.CreateVirtualMFADeviceOutput{
+				VirtualMFADevice: &types.VirtualMFADevice{
+					SerialNumber:     aws.String("arn:aws:iam::123456789123:mfa/alice-device"),
+					Base32StringSeed: []byte("JBSWY3DPEHPK3PXP"),
+					QRCodePNG:        []byte("PNGDATA"),
+				},
+			}, nil
+		},
+		enableFn: func(ctx context.Context, params *iam.EnableMFADeviceInput, optFns ...func(*iam.Options)) (*iam.EnableMFADeviceOutput, error) {
+			enabledSerial = *params.SerialNumber
+			enabledCode1 = *params.AuthenticationCode1
+			enabledCode2 = *params.AuthenticationCode2
+			return &iam.EnableMFADeviceOutput{}, nil
+		},
+	}
+
+	if err := runProvision(api, opt, codeReader("111111", "222222")); err != nil {
+		t.Fatal(err)
+	}
+
+	if enabledSerial != "arn:aws:iam::123456789123:mfa/alice-device" {
+		t.Errorf("got serial %q", enabledSerial)
+	}
+	if enabledCode1 != "111111" || enabledCode2 != "222222" {
+		t.Errorf("got codes %q, %q", enabledCode1, enabledCode2)
+	}
+
+	seed, err := os.ReadFile(filepath.Join(dir, "alice-device.seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(seed) != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("got seed file content %q", seed)
+	}
+	png, err := os.ReadFile(filepath.Join(dir, "alice-device.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(png) != "PNGDATA" {
+		t.Errorf("got png file content %q", png)
+	}
+}
+
+func TestRunProvisionRefusesOverwriteWithoutForce(t *testing.T) {
+	dir := t.TempDir()
+	opt := &provisionOptions{user: "alice", name: "alice-device", outDir: dir}
+	if err := os.WriteFile(filepath.Join(dir, "alice-device.seed"), []byte("existing"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	api := mockIAMMFAAPI{
+		createFn: func(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error) {
+			t.Fatal("CreateVirtualMFADevice should not be called when the seed file already exists")
+			return nil, nil
+		},
+	}
+
+	err := runProvision(api, opt, codeReader("111111", "222222"))
+	if err != errSeedFileExists {
+		t.Errorf("got error %v, want errSeedFileExists", err)
+	}
+}
+
+func TestRunProvisionInvalidCode(t *testing.T) {
+	dir := t.TempDir()
+	opt := &provisionOptions{user: "alice", name: "alice-device", outDir: dir}
+
+	api := mockIAMMFAAPI{
+		createFn: func(ctx context.Context, params *iam.CreateVirtualMFADeviceInput, optFns ...func(*iam.Options)) (*iam.CreateVirtualMFADeviceOutput, error) {
+			return &iam.CreateVirtualMFADeviceOutput{
+				VirtualMFADevice: &types.VirtualMFADevice{
+					SerialNumber:     aws.String("arn:aws:iam::123456789123:mfa/alice-device"),
+					Base32StringSeed: []byte("JBSWY3DPEHPK3PXP"),
+					QRCodePNG:        []byte("PNGDATA"),
+				},
+			}, nil
+		},
+		enableFn: func(ctx context.Context, params *iam.EnableMFADeviceInput, optFns ...func(*iam.Options)) (*iam.EnableMFADeviceOutput, error) {
+			t.Fatal("EnableMFADevice should not be called with an invalid code")
+			return nil, nil
+		},
+	}
+
+	err := runProvision(api, opt, codeReader("not-a-code", "222222"))
+	if err != errInvalidCode {
+		t.Errorf("got error %v, want errInvalidCode", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// printShellExports writes eval-ready statements that set the AWS credential
+// environment variables for the requested shell, e.g. for use as
+// `eval $(mfaprof -shell bash ...)`. The caller is expected to have already
+// validated that shell is one of supportedShells.
+func printShellExports(w io.Writer, shell string, region string, identity *sts.GetCallerIdentityOutput, creds *types.Credentials) error {
+	expires := creds.Expiration.UTC().Format(time.RFC3339)
+
+	switch shell {
+	case "bash", "zsh":
+		fmt.Fprintf(w, "export AWS_ACCESS_KEY_ID=%s\n", *creds.AccessKeyId)
+		fmt.Fprintf(w, "export AWS_SECRET_ACCESS_KEY=%s\n", *creds.SecretAccessKey)
+		fmt.Fprintf(w, "export AWS_SESSION_TOKEN=%s\n", *creds.SessionToken)
+		if region != "" {
+			fmt.Fprintf(w, "export AWS_DEFAULT_REGION=%s\n", region)
+		}
+		fmt.Fprintf(w, "export EXPIRES=%s\n", expires)
+		fmt.Fprintf(w, "# Authenticated as %s\n", *identity.Arn)
+	case "fish":
+		fmt.Fprintf(w, "set -gx AWS_ACCESS_KEY_ID %s;\n", *creds.AccessKeyId)
+		fmt.Fprintf(w, "set -gx AWS_SECRET_ACCESS_KEY %s;\n", *creds.SecretAccessKey)
+		fmt.Fprintf(w, "set -gx AWS_SESSION_TOKEN %s;\n", *creds.SessionToken)
+		if region != "" {
+			fmt.Fprintf(w, "set -gx AWS_DEFAULT_REGION %s;\n", region)
+		}
+		fmt.Fprintf(w, "set -gx EXPIRES %s;\n", expires)
+		fmt.Fprintf(w, "# Authenticated as %s\n", *identity.Arn)
+	case "powershell":
+		fmt.Fprintf(w, "$env:AWS_ACCESS_KEY_ID = \"%s\"\n", *creds.AccessKeyId)
+		fmt.Fprintf(w, "$env:AWS_SECRET_ACCESS_KEY = \"%s\"\n", *creds.SecretAccessKey)
+		fmt.Fprintf(w, "$env:AWS_SESSION_TOKEN = \"%s\"\n", *creds.SessionToken)
+		if region != "" {
+			fmt.Fprintf(w, "$env:AWS_DEFAULT_REGION = \"%s\"\n", region)
+		}
+		fmt.Fprintf(w, "# Authenticated as %s\n", *identity.Arn)
+		fmt.Fprintf(w, "$env:EXPIRES = \"%s\"\n", expires)
+	default:
+		return errInvalidShell
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestPrintShellExports(t *testing.T) {
+	expires := time.Date(2026, 7, 25, 12, 0, 0, 0, time.UTC)
+	identity := &sts.GetCallerIdentityOutput{Arn: aws.String("arn:aws:iam::123456789123:user/alice")}
+	stsOutput := &sts.GetSessionTokenOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("AKIAEXAMPLE"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      &expires,
+		},
+	}
+
+	var tests = []struct {
+		name    string
+		shell   string
+		want    []string
+		wantErr error
+	}{
+		{"bash", "bash", []string{
+			"export AWS_ACCESS_KEY_ID=AKIAEXAMPLE",
+			"export AWS_SECRET_ACCESS_KEY=secret",
+			"export AWS_SESSION_TOKEN=token",
+			"export AWS_DEFAULT_REGION=us-east-1",
+			"export EXPIRES=2026-07-25T12:00:00Z",
+		}, nil},
+		{"fish", "fish", []string{
+			"set -gx AWS_ACCESS_KEY_ID AKIAEXAMPLE;",
+			"set -gx EXPIRES 2026-07-25T12:00:00Z;",
+		}, nil},
+		{"powershell", "powershell", []string{
+			`$env:AWS_ACCESS_KEY_ID = "AKIAEXAMPLE"`,
+			`$env:EXPIRES = "2026-07-25T12:00:00Z"`,
+		}, nil},
+		{"unsupported", "tcsh", nil, errInvalidShell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := printShellExports(&buf, tt.shell, "us-east-1", identity, stsOutput.Credentials)
+			if err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q, got:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const totpStep = 30 * time.Second
+
+// generateTOTP computes an RFC 6238 TOTP code for secret (a base32 string)
+// at time t: HMAC-SHA1 over the big-endian 8-byte 30-second counter, then
+// dynamic truncation into a zero-padded 6-digit code.
+func generateTOTP(secret string, t time.Time) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", fmt.Errorf("invalid base32 TOTP secret: %w", err)
+	}
+
+	counter := uint64(t.Unix() / int64(totpStep/time.Second))
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := (uint32(sum[offset]&0x7f) << 24) |
+		(uint32(sum[offset+1]) << 16) |
+		(uint32(sum[offset+2]) << 8) |
+		uint32(sum[offset+3])
+	code %= 1000000
+
+	return fmt.Sprintf("%06d", code), nil
+}
+
+// totpCandidates returns TOTP codes for steps T-window..T+window, nearest to
+// the local clock first, so a caller retrying each one against AWS tries the
+// most likely match before drifting further.
+func totpCandidates(secret string, window int) []string {
+	now := time.Now()
+	codes := make([]string, 0, 2*window+1)
+	if code, err := generateTOTP(secret, now); err == nil {
+		codes = append(codes, code)
+	}
+	for i := 1; i <= window; i++ {
+		if code, err := generateTOTP(secret, now.Add(-time.Duration(i)*totpStep)); err == nil {
+			codes = append(codes, code)
+		}
+		if code, err := generateTOTP(secret, now.Add(time.Duration(i)*totpStep)); err == nil {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the base32 encoding of the ASCII test seed
+// "12345678901234567890" used by the RFC 6238 Appendix B test vectors.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTP(t *testing.T) {
+	var tests = []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		// Expected values are the low 6 digits of the RFC 6238 Appendix B
+		// SHA1 test vectors (which define an 8-digit OTP).
+		{"T59", time.Unix(59, 0).UTC(), "287082"},
+		{"T1111111109", time.Unix(1111111109, 0).UTC(), "081804"},
+		{"T1111111111", time.Unix(1111111111, 0).UTC(), "050471"},
+		{"T1234567890", time.Unix(1234567890, 0).UTC(), "005924"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateTOTP(rfc6238Secret, tt.time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPInvalidSecret(t *testing.T) {
+	if _, err := generateTOTP("not-base32!!!", time.Unix(59, 0)); err == nil {
+		t.Error("expected an error for a non-base32 secret")
+	}
+}
+
+func TestTOTPCandidatesWindow(t *testing.T) {
+	for _, window := range []int{0, 1, 3} {
+		codes := totpCandidates(rfc6238Secret, window)
+		want := 2*window + 1
+		if len(codes) != want {
+			t.Errorf("window %d: got %d candidates, want %d", window, len(codes), want)
+		}
+		current, err := generateTOTP(rfc6238Secret, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes[0] != current {
+			t.Errorf("window %d: got first candidate %q, want current code %q", window, codes[0], current)
+		}
+	}
+}